@@ -0,0 +1,167 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// icuArg describes a single top-level ICU MessageFormat argument found in a
+// string, e.g. the "count" in "{count, plural, one {...} other {...}}".
+type icuArg struct {
+	// name is the argument name.
+	name string
+
+	// kind is "plural", "selectordinal", "select", or "" for a plain
+	// "{name}" placeholder.
+	kind string
+
+	// categories are the case labels found in a plural/selectordinal/select
+	// argument, e.g. "one", "few", "other", or "=0".
+	categories []string
+}
+
+// argNameRe matches the leading identifier of a top-level ICU argument.
+var argNameRe = regexp.MustCompile(`^\s*([A-Za-z0-9_]+)\s*(?:,\s*([A-Za-z]+)\s*(?:,\s*(.*))?)?$`)
+
+// categoryRe matches a plural/select case label immediately followed by its
+// opening brace, e.g. "one {" or "=0 {".
+var categoryRe = regexp.MustCompile(`(=\d+|[A-Za-z]+)\s*\{`)
+
+// parseICU extracts the top-level arguments from an ICU MessageFormat string
+// and reports whether its braces are balanced.  It is not a full ICU parser:
+// it only goes deep enough to find argument names, their plural/select kind,
+// and the case labels used, which is what lint needs.
+func parseICU(s string) (args []icuArg, balanced bool) {
+	depth := 0
+	i := 0
+	for i < len(s) {
+		switch s[i] {
+		case '{':
+			start := i + 1
+			end, ok := matchingBrace(s, i)
+			if !ok {
+				depth++
+				i++
+
+				continue
+			}
+
+			body := s[start:end]
+			if arg, ok := parseICUArg(body); ok {
+				args = append(args, arg)
+			}
+
+			i = end + 1
+		case '}':
+			depth++
+			i++
+		default:
+			i++
+		}
+	}
+
+	return args, depth == 0
+}
+
+// matchingBrace returns the index of the "}" that matches the "{" at s[open]
+// and true, or (0, false) if it has no match.
+func matchingBrace(s string, open int) (close int, ok bool) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// parseICUArg parses the body of a top-level "{...}" argument, e.g.
+// `count, plural, one {one item} other {# items}`.
+func parseICUArg(body string) (arg icuArg, ok bool) {
+	m := argNameRe.FindStringSubmatch(body)
+	if m == nil {
+		return icuArg{}, false
+	}
+
+	arg = icuArg{name: m[1], kind: strings.ToLower(m[2])}
+
+	switch arg.kind {
+	case "plural", "selectordinal", "select":
+		for _, cm := range categoryRe.FindAllStringSubmatch(m[3], -1) {
+			arg.categories = append(arg.categories, cm[1])
+		}
+	default:
+		arg.kind = ""
+	}
+
+	return arg, true
+}
+
+// tagRe matches an HTML/XML-like start or end tag.
+var tagRe = regexp.MustCompile(`<(/?)([A-Za-z][A-Za-z0-9]*)[^<>]*?(/?)>`)
+
+// voidElements are HTML elements that never have a closing tag, with or
+// without a trailing "/"; translations are free to write them either way.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// unbalancedTags returns the names of tags in s whose open/close pairs don't
+// match, in the order the mismatch was found.
+func unbalancedTags(s string) (mismatches []string) {
+	var stack []string
+	for _, m := range tagRe.FindAllStringSubmatch(s, -1) {
+		closing, name, selfClosing := m[1] == "/", m[2], m[3] == "/"
+		if selfClosing || voidElements[strings.ToLower(name)] {
+			continue
+		}
+
+		if !closing {
+			stack = append(stack, name)
+
+			continue
+		}
+
+		if len(stack) == 0 || stack[len(stack)-1] != name {
+			mismatches = append(mismatches, name)
+
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+	}
+
+	mismatches = append(mismatches, stack...)
+
+	return mismatches
+}
+
+// pluralCategories returns the CLDR plural categories required for lang.
+// This is a simplified subset of CLDR's plural rules, covering the language
+// families shipped in localesDir; languages not listed fall back to the
+// common "one"/"other" set.
+func pluralCategories(lang langCode) (categories []string) {
+	switch lang {
+	case "ja", "ko", "zh", "vi", "th", "id", "ms":
+		return []string{"other"}
+	case "ru", "uk", "be":
+		return []string{"one", "few", "many", "other"}
+	case "sr", "hr", "bs":
+		return []string{"one", "few", "other"}
+	case "pl", "cs", "sk":
+		return []string{"one", "few", "many", "other"}
+	case "ar":
+		return []string{"zero", "one", "two", "few", "many", "other"}
+	default:
+		return []string{"one", "other"}
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// lintIssueKind is the kind of a problem found by lint.
+type lintIssueKind string
+
+// Kinds of lint issues.
+const (
+	lintMissingPlaceholder lintIssueKind = "missing_placeholder"
+	lintExtraPlaceholder   lintIssueKind = "extra_placeholder"
+	lintPluralMismatch     lintIssueKind = "plural_mismatch"
+	lintUnbalancedBraces   lintIssueKind = "unbalanced_braces"
+	lintUnbalancedTags     lintIssueKind = "unbalanced_tags"
+	lintLengthRatio        lintIssueKind = "length_ratio"
+)
+
+// lintIssue is a single problem found in a translated string.
+type lintIssue struct {
+	Lang    langCode      `json:"lang"`
+	Key     textLabel     `json:"key"`
+	Kind    lintIssueKind `json:"kind"`
+	Message string        `json:"message"`
+}
+
+// lintReport is the result of a lint run, suitable for JSON output.
+type lintReport struct {
+	Issues []lintIssue `json:"issues"`
+}
+
+// lint validates every locale in langs against the base locale: ICU
+// MessageFormat placeholders, CLDR plural categories, brace and tag
+// balance, and suspiciously long translations.  It prints the report to
+// stdout and exits with a non-zero status if any issues were found.
+func lint(conf twoskyConf) (err error) {
+	var format string
+	var ratio float64
+
+	flagSet := flag.NewFlagSet("lint", flag.ExitOnError)
+	flagSet.Usage = func() {
+		usage("lint command error")
+	}
+	flagSet.StringVar(&format, "format", "text", `output format: "text" or "json"`)
+	flagSet.Float64Var(&ratio, "ratio", 4, "max allowed translation/base length ratio")
+
+	err = flagSet.Parse(os.Args[2:])
+	if err != nil {
+		// Don't wrap the error since there is exit on error.
+		return err
+	}
+
+	if format != "text" && format != "json" {
+		usage(fmt.Sprintf("lint: unknown format %q", format))
+	}
+
+	basePath := filepath.Join(localesDir, defaultBaseFile)
+	baseLoc, err := readLocales(basePath)
+	if err != nil {
+		return fmt.Errorf("lint: %w", err)
+	}
+
+	langs := maps.Keys(conf.Languages)
+	slices.Sort(langs)
+
+	var issues []lintIssue
+	for _, lang := range langs {
+		if lang == conf.BaseLangcode {
+			continue
+		}
+
+		name := filepath.Join(localesDir, string(lang)+".json")
+		loc, lErr := readLocales(name)
+		if lErr != nil {
+			return fmt.Errorf("lint: %w", lErr)
+		}
+
+		issues = append(issues, lintLocale(lang, baseLoc, loc, ratio)...)
+	}
+
+	if format == "json" {
+		b, mErr := json.MarshalIndent(lintReport{Issues: issues}, "", "\t")
+		if mErr != nil {
+			return fmt.Errorf("lint: marshalling report: %w", mErr)
+		}
+
+		fmt.Println(string(b))
+	} else {
+		for _, iss := range issues {
+			fmt.Printf("%s\t%s\t%s\t%s\n", iss.Lang, iss.Key, iss.Kind, iss.Message)
+		}
+	}
+
+	if len(issues) > 0 {
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+// lintLocale compares loc against baseLoc for a single lang and returns the
+// issues found.
+func lintLocale(lang langCode, baseLoc, loc locales, ratio float64) (issues []lintIssue) {
+	keys := maps.Keys(baseLoc)
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		base, translated := baseLoc[key], loc[key]
+		if translated == "" {
+			// Untranslated strings are reported by summary, not lint.
+			continue
+		}
+
+		issues = append(issues, lintString(lang, key, base, translated, ratio)...)
+	}
+
+	return issues
+}
+
+// lintString checks a single translated string against its base version.
+func lintString(lang langCode, key textLabel, base, translated string, ratio float64) (issues []lintIssue) {
+	baseArgs, baseBalanced := parseICU(base)
+	trArgs, trBalanced := parseICU(translated)
+
+	if !trBalanced {
+		issues = append(issues, lintIssue{
+			Lang:    lang,
+			Key:     key,
+			Kind:    lintUnbalancedBraces,
+			Message: "unbalanced { or } in translation",
+		})
+	}
+
+	if baseBalanced && trBalanced {
+		issues = append(issues, lintPlaceholders(lang, key, baseArgs, trArgs)...)
+	}
+
+	if tags := unbalancedTags(translated); len(tags) > 0 {
+		issues = append(issues, lintIssue{
+			Lang:    lang,
+			Key:     key,
+			Kind:    lintUnbalancedTags,
+			Message: fmt.Sprintf("unbalanced HTML tag(s): %v", tags),
+		})
+	}
+
+	if len(base) > 0 && float64(len(translated)) > ratio*float64(len(base)) {
+		issues = append(issues, lintIssue{
+			Lang: lang,
+			Key:  key,
+			Kind: lintLengthRatio,
+			Message: fmt.Sprintf(
+				"translation is %dx longer than the base string (%d vs %d bytes)",
+				len(translated)/len(base), len(translated), len(base),
+			),
+		})
+	}
+
+	return issues
+}
+
+// lintPlaceholders compares the ICU arguments found in the base and
+// translated strings.
+func lintPlaceholders(lang langCode, key textLabel, baseArgs, trArgs []icuArg) (issues []lintIssue) {
+	trByName := make(map[string]icuArg, len(trArgs))
+	for _, a := range trArgs {
+		trByName[a.name] = a
+	}
+
+	baseNames := make(map[string]bool, len(baseArgs))
+	for _, a := range baseArgs {
+		baseNames[a.name] = true
+
+		tr, ok := trByName[a.name]
+		if !ok {
+			issues = append(issues, lintIssue{
+				Lang:    lang,
+				Key:     key,
+				Kind:    lintMissingPlaceholder,
+				Message: fmt.Sprintf("placeholder %q is missing from the translation", a.name),
+			})
+
+			continue
+		}
+
+		if a.kind == "plural" || a.kind == "selectordinal" {
+			issues = append(issues, lintPluralCategories(lang, key, a.name, tr.categories)...)
+		}
+	}
+
+	for _, a := range trArgs {
+		if !baseNames[a.name] {
+			issues = append(issues, lintIssue{
+				Lang:    lang,
+				Key:     key,
+				Kind:    lintExtraPlaceholder,
+				Message: fmt.Sprintf("placeholder %q doesn't exist in the base string", a.name),
+			})
+		}
+	}
+
+	return issues
+}
+
+// lintPluralCategories checks that a plural/selectordinal argument in the
+// translation covers every CLDR category the language requires.
+func lintPluralCategories(lang langCode, key textLabel, arg string, have []string) (issues []lintIssue) {
+	haveSet := make(map[string]bool, len(have))
+	for _, c := range have {
+		haveSet[c] = true
+	}
+
+	for _, want := range pluralCategories(lang) {
+		if !haveSet[want] {
+			issues = append(issues, lintIssue{
+				Lang: lang,
+				Key:  key,
+				Kind: lintPluralMismatch,
+				Message: fmt.Sprintf(
+					"plural argument %q is missing required CLDR category %q", arg, want,
+				),
+			})
+		}
+	}
+
+	return issues
+}
+
+// i18nKeepRe matches a `// i18n-keep: foo, bar` comment and captures the
+// comma-separated key list.
+var i18nKeepRe = regexp.MustCompile(`//\s*i18n-keep:\s*(.+)`)
+
+// splitI18nKeepList splits the captured key list of an i18n-keep comment on
+// commas and trims whitespace around each entry, dropping empty entries.
+func splitI18nKeepList(s string) (keys []string) {
+	for _, k := range strings.Split(s, ",") {
+		k = strings.TrimSpace(k)
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// scanI18nKeep scans fileNames for "// i18n-keep: foo, bar" comments and
+// returns the text labels they list.  These whitelist locale keys that are
+// only referenced via computed i18next keys, e.g. `t(\`prefix_${x}\`)`,
+// which removeUnused's literal string search can't find on its own.
+func scanI18nKeep(fileNames []string) (kept map[textLabel]bool, err error) {
+	kept = map[textLabel]bool{}
+
+	for _, fn := range fileNames {
+		if filepath.Ext(fn) == ".json" {
+			continue
+		}
+
+		var b []byte
+		b, err = os.ReadFile(fn)
+		if err != nil {
+			// Don't wrap the error since it's informative enough as is.
+			return nil, err
+		}
+
+		for _, keys := range i18nKeepRe.FindAllStringSubmatch(string(b), -1) {
+			for _, k := range splitI18nKeepList(keys[1]) {
+				kept[textLabel(k)] = true
+			}
+		}
+	}
+
+	return kept, nil
+}
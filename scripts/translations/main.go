@@ -4,23 +4,27 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
-	"github.com/AdguardTeam/AdGuardHome/internal/aghio"
+	"github.com/AdguardTeam/AdGuardHome/scripts/translations/provider"
 	"github.com/AdguardTeam/golibs/errors"
 	"github.com/AdguardTeam/golibs/log"
+	"github.com/cheggaaa/pb/v3"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -31,7 +35,10 @@ const (
 	srcDir           = "./client/src"
 	twoskyURI        = "https://twosky.int.agrd.dev/api/v1"
 
-	readLimit = 1 * 1024 * 1024
+	// downloadStateFile is the name of the file that keeps track of the
+	// locale files successfully written by the previous download run.  It
+	// is stored next to localesDir.
+	downloadStateFile = ".download-state.json"
 )
 
 // langCode is a language code.
@@ -60,9 +67,6 @@ func main() {
 		uriStr = twoskyURI
 	}
 
-	uri, err := url.Parse(uriStr)
-	check(err)
-
 	projectID := os.Getenv("TWOSKY_PROJECT_ID")
 	if projectID == "" {
 		projectID = defaultProjectID
@@ -76,19 +80,54 @@ func main() {
 		err = summary(conf.Languages)
 		check(err)
 	case "download":
-		err = download(uri, projectID, conf.Languages)
+		p, pErr := newProvider(conf, uriStr, projectID)
+		check(pErr)
+
+		err = download(p, conf.Languages)
+		check(err)
+	case "lint":
+		err = lint(conf)
 		check(err)
 	case "unused":
 		err = unused()
 		check(err)
 	case "upload":
-		err = upload(uri, projectID, conf.BaseLangcode)
+		p, pErr := newProvider(conf, uriStr, projectID)
+		check(pErr)
+
+		err = upload(p, conf.BaseLangcode)
 		check(err)
 	default:
 		usage("unknown command")
 	}
 }
 
+// newProvider builds the translation Provider selected by the
+// TRANSLATION_PROVIDER environment variable or, failing that, the
+// "provider" field of conf.
+func newProvider(conf twoskyConf, uriStr, projectID string) (p provider.Provider, err error) {
+	name := provider.Name(os.Getenv("TRANSLATION_PROVIDER"))
+	if name == "" {
+		name = conf.Provider
+	}
+
+	pConf := &provider.Config{
+		BaseURL:      uriStr,
+		Token:        os.Getenv("CROWDIN_TOKEN"),
+		ProjectID:    projectID,
+		BaseLangCode: string(conf.BaseLangcode),
+		BaseFile:     defaultBaseFile,
+		LocalDir:     os.Getenv("TRANSLATION_LOCAL_DIR"),
+	}
+
+	p, err = provider.New(name, pConf)
+	if err != nil {
+		return nil, fmt.Errorf("creating provider: %w", err)
+	}
+
+	return p, nil
+}
+
 // check is a simple error-checking helper for scripts.
 func check(err error) {
 	if err != nil {
@@ -105,8 +144,16 @@ Commands:
         Print usage.
   summary
         Print summary.
-  download [-n <count>]
-        Download translations. count is a number of concurrent downloads.
+  download [-n <count>] [-rps <n>] [-force] [-silent] [-no-progress]
+        Download translations. count is a number of concurrent downloads,
+        rate-limited to rps requests per second in total. force re-downloads
+        locales that are already up to date. silent and no-progress suppress
+        output and the progress bar, respectively, for use in CI.
+  lint [-format=text|json] [-ratio <n>]
+        Validate translations: ICU placeholders, CLDR plurals, balanced
+        braces and tags, and translation/base length ratio. Exits non-zero
+        if any issues are found. -format=json prints a machine-readable
+        report instead of text.
   unused
         Print unused strings.
   upload
@@ -125,10 +172,11 @@ Commands:
 
 // twoskyConf is the configuration structure for localization.
 type twoskyConf struct {
-	Languages        languages `json:"languages"`
-	ProjectID        string    `json:"project_id"`
-	BaseLangcode     langCode  `json:"base_locale"`
-	LocalizableFiles []string  `json:"localizable_files"`
+	Languages        languages     `json:"languages"`
+	ProjectID        string        `json:"project_id"`
+	BaseLangcode     langCode      `json:"base_locale"`
+	LocalizableFiles []string      `json:"localizable_files"`
+	Provider         provider.Name `json:"provider"`
 }
 
 // readTwoskyConf returns configuration.
@@ -217,16 +265,106 @@ func summary(langs languages) (err error) {
 	return nil
 }
 
-// download and save all translations.  uri is the base URL.  projectID is the
-// name of the project.
-func download(uri *url.URL, projectID string, langs languages) (err error) {
+// fileState is the recorded state of a single successfully downloaded locale
+// file.
+type fileState struct {
+	SHA256  string    `json:"sha256"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// downloadState is the persisted state of the last successful download run,
+// keyed by language code.
+type downloadState map[langCode]fileState
+
+// readDownloadState reads the download state file.  It returns an empty
+// state and no error if the file doesn't exist yet.
+func readDownloadState(fn string) (st downloadState, err error) {
+	b, err := os.ReadFile(fn)
+	if errors.Is(err, os.ErrNotExist) {
+		return downloadState{}, nil
+	} else if err != nil {
+		// Don't wrap the error since it's informative enough as is.
+		return nil, err
+	}
+
+	st = downloadState{}
+	err = json.Unmarshal(b, &st)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling %q: %w", fn, err)
+	}
+
+	return st, nil
+}
+
+// writeDownloadState writes st to fn.
+func writeDownloadState(fn string, st downloadState) (err error) {
+	b, err := json.MarshalIndent(st, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshalling: %w", err)
+	}
+
+	err = os.WriteFile(fn, b, 0o644)
+	if err != nil {
+		// Don't wrap the error since it's informative enough as is.
+		return err
+	}
+
+	return nil
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 hash sum of the file at fn.
+func fileSHA256(fn string) (sum string, err error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		// Don't wrap the error since it's informative enough as is.
+		return "", err
+	}
+
+	h := sha256.Sum256(b)
+
+	return hex.EncodeToString(h[:]), nil
+}
+
+// upToDate returns true if the locale file for lang already matches the
+// recorded state in st.
+func upToDate(st downloadState, lang langCode, name string) (ok bool) {
+	rec, has := st[lang]
+	if !has {
+		return false
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return false
+	}
+
+	if !info.ModTime().Equal(rec.ModTime) {
+		return false
+	}
+
+	sum, err := fileSHA256(name)
+	if err != nil {
+		return false
+	}
+
+	return sum == rec.SHA256
+}
+
+// download and save all translations using p.
+func download(p provider.Provider, langs languages) (err error) {
 	var numWorker int
+	var rps float64
+	var force, silent, noProgress bool
 
 	flagSet := flag.NewFlagSet("download", flag.ExitOnError)
 	flagSet.Usage = func() {
 		usage("download command error")
 	}
 	flagSet.IntVar(&numWorker, "n", 1, "number of concurrent downloads")
+	flagSet.Float64Var(&rps, "rps", 5, "max requests per second shared across all workers")
+	flagSet.BoolVar(&force, "force", false, "re-download locales even if they're up to date")
+	flagSet.BoolVar(&silent, "silent", false, "suppress all non-essential output")
+	flagSet.BoolVar(&noProgress, "no-progress", false, "don't render the progress bar")
 
 	err = flagSet.Parse(os.Args[2:])
 	if err != nil {
@@ -238,105 +376,182 @@ func download(uri *url.URL, projectID string, langs languages) (err error) {
 		usage("count must be positive")
 	}
 
-	downloadURI := uri.JoinPath("download")
+	if rps <= 0 {
+		usage("rps must be positive")
+	}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	statePath := filepath.Join(localesDir, downloadStateFile)
+	state, err := readDownloadState(statePath)
+	if err != nil {
+		return fmt.Errorf("download: reading state: %w", err)
 	}
 
-	var wg sync.WaitGroup
-	uriCh := make(chan *url.URL, len(langs))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	for i := 0; i < numWorker; i++ {
-		wg.Add(1)
-		go downloadWorker(&wg, client, uriCh)
-	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	go func() {
+		if _, ok := <-sigCh; ok {
+			log.Info("download: received interrupt, canceling")
+			cancel()
+		}
+	}()
 
+	var pending []langCode
 	for lang := range langs {
-		uri = translationURL(downloadURI, defaultBaseFile, projectID, lang)
+		name := filepath.Join(localesDir, string(lang)+".json")
+		if !force && upToDate(state, lang, name) {
+			if !silent {
+				fmt.Printf("%s: up to date, skipping\n", name)
+			}
 
-		uriCh <- uri
-	}
+			continue
+		}
 
-	close(uriCh)
-	wg.Wait()
+		pending = append(pending, lang)
+	}
 
-	return nil
-}
+	var pool *pb.Pool
+	tmpl := `{{ string . "file" }} {{ bar . }} {{ counters . }} {{ speed . }} {{ rtime . "ETA %s" }}`
+	bars := make([]*pb.ProgressBar, numWorker)
+	for i := range bars {
+		bars[i] = pb.New(0).SetTemplateString(tmpl)
+	}
 
-// downloadWorker downloads translations by received urls and saves them.
-func downloadWorker(wg *sync.WaitGroup, client *http.Client, uriCh <-chan *url.URL) {
-	defer wg.Done()
+	aggTmpl := `total {{ bar . }} {{ counters . }} {{ percent . }} {{ rtime . "ETA %s" }}`
+	aggBar := pb.New(len(pending)).SetTemplateString(aggTmpl)
 
-	for uri := range uriCh {
-		data, err := getTranslation(client, uri.String())
+	if !silent && !noProgress && len(pending) > 0 {
+		pool, err = pb.StartPool(append(bars, aggBar)...)
 		if err != nil {
-			log.Error("download worker: getting translation: %s", err)
-
-			continue
+			return fmt.Errorf("download: starting progress pool: %w", err)
 		}
+	}
 
-		q := uri.Query()
-		code := q.Get("language")
+	limiter := rate.NewLimiter(rate.Limit(rps), 1)
 
-		name := filepath.Join(localesDir, code+".json")
-		err = os.WriteFile(name, data, 0o664)
-		if err != nil {
-			log.Error("download worker: writing file: %s", err)
+	var wg sync.WaitGroup
+	var stateMu sync.Mutex
+	langCh := make(chan langCode, len(pending))
 
-			continue
-		}
+	errs := make([]error, numWorker)
+	for i := 0; i < numWorker; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
 
-		fmt.Println(name)
+			errs[i] = downloadWorker(ctx, p, langCh, bars[i], aggBar, limiter, state, &stateMu)
+		}(i)
 	}
-}
 
-// getTranslation returns received translation data or error.
-func getTranslation(client *http.Client, url string) (data []byte, err error) {
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("requesting: %w", err)
+	for _, lang := range pending {
+		langCh <- lang
 	}
+	close(langCh)
 
-	defer log.OnCloserError(resp.Body, log.ERROR)
-
-	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("url: %q; status code: %s", url, http.StatusText(resp.StatusCode))
+	wg.Wait()
 
-		return nil, err
+	if pool != nil {
+		_ = pool.Stop()
 	}
 
-	limitReader, err := aghio.LimitReader(resp.Body, readLimit)
+	err = writeDownloadState(statePath, state)
 	if err != nil {
-		err = fmt.Errorf("limit reading: %w", err)
+		log.Error("download: writing state: %s", err)
+	}
 
-		return nil, err
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("download: aborted: %w", ctxErr)
 	}
 
-	data, err = io.ReadAll(limitReader)
-	if err != nil {
-		err = fmt.Errorf("reading all: %w", err)
+	var errsList errors.List
+	for _, e := range errs {
+		if e != nil {
+			errsList.Add(e)
+		}
+	}
 
-		return nil, err
+	if len(errsList) > 0 {
+		return fmt.Errorf("download: %w", errsList)
 	}
 
-	return data, nil
+	return nil
 }
 
-// translationURL returns a new url.URL with provided query parameters.
-func translationURL(oldURL *url.URL, baseFile, projectID string, lang langCode) (uri *url.URL) {
-	uri = &url.URL{}
-	*uri = *oldURL
+// downloadWorker downloads translations via p and saves them, reporting the
+// bytes transferred for the file currently in flight on bar and the count of
+// files completed so far on aggBar, which is shared between all workers.  It
+// updates st for every file it writes successfully, guarded by stateMu since
+// st is shared between workers.
+func downloadWorker(
+	ctx context.Context,
+	p provider.Provider,
+	langCh <-chan langCode,
+	bar *pb.ProgressBar,
+	aggBar *pb.ProgressBar,
+	limiter *rate.Limiter,
+	st downloadState,
+	stateMu *sync.Mutex,
+) (err error) {
+	for lang := range langCh {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if wErr := limiter.Wait(ctx); wErr != nil {
+			return wErr
+		}
+
+		code := string(lang)
+		fileName := code + ".json"
+		bar.Set("file", fileName)
+		bar.SetTotal(0)
+		bar.SetCurrent(0)
+
+		data, gErr := p.Download(ctx, code, func(read, total int64) {
+			if total > 0 {
+				bar.SetTotal(total)
+			}
+
+			bar.SetCurrent(read)
+		})
+		if gErr != nil {
+			log.Error("download worker: getting translation: %s", gErr)
+			err = errors.WithDeferred(err, gErr)
+			aggBar.Increment()
 
-	q := uri.Query()
-	q.Set("format", "json")
-	q.Set("filename", baseFile)
-	q.Set("project", projectID)
-	q.Set("language", string(lang))
+			continue
+		}
+
+		name := filepath.Join(localesDir, fileName)
+		wErr := os.WriteFile(name, data, 0o664)
+		if wErr != nil {
+			log.Error("download worker: writing file: %s", wErr)
+			err = errors.WithDeferred(err, wErr)
+			aggBar.Increment()
+
+			continue
+		}
+
+		info, sErr := os.Stat(name)
+		sum := sha256.Sum256(data)
+		if sErr == nil {
+			stateMu.Lock()
+			st[lang] = fileState{
+				SHA256:  hex.EncodeToString(sum[:]),
+				ModTime: info.ModTime(),
+			}
+			stateMu.Unlock()
+		}
 
-	uri.RawQuery = q.Encode()
+		aggBar.Increment()
+		fmt.Println(name)
+	}
 
-	return uri
+	return err
 }
 
 // unused prints unused text labels.
@@ -377,20 +592,36 @@ func unused() (err error) {
 		return fmt.Errorf("filepath walking %q: %w", srcDir, err)
 	}
 
-	err = removeUnused(fileNames, baseLoc)
+	kept, err := scanI18nKeep(fileNames)
+	if err != nil {
+		return fmt.Errorf("unused: scanning i18n-keep comments: %w", err)
+	}
+
+	err = removeUnused(fileNames, baseLoc, kept)
 
 	return errors.Annotate(err, "removing unused: %w")
 }
 
-func removeUnused(fileNames []string, loc locales) (err error) {
-	knownUsed := []textLabel{
-		"blocking_mode_refused",
-		"blocking_mode_nxdomain",
-		"blocking_mode_custom_ip",
+// legacyKnownUsed lists text labels referenced only via computed i18next
+// keys whose call sites don't carry an "// i18n-keep:" comment (see
+// scanI18nKeep) yet.  Remove an entry here once its call site is updated to
+// carry the comment instead.
+var legacyKnownUsed = []textLabel{
+	"blocking_mode_refused",
+	"blocking_mode_nxdomain",
+	"blocking_mode_custom_ip",
+}
+
+// removeUnused deletes from loc every key that is used: found verbatim in
+// one of fileNames, whitelisted in kept (see scanI18nKeep), or listed in
+// legacyKnownUsed.  It then prints whatever remains.
+func removeUnused(fileNames []string, loc locales, kept map[textLabel]bool) (err error) {
+	for k := range kept {
+		delete(loc, k)
 	}
 
-	for _, v := range knownUsed {
-		delete(loc, v)
+	for _, k := range legacyKnownUsed {
+		delete(loc, k)
 	}
 
 	for _, fn := range fileNames {
@@ -423,11 +654,8 @@ func printUnused(loc locales) {
 	}
 }
 
-// upload base translation.  uri is the base URL.  projectID is the name of the
-// project.  baseLang is the base language code.
-func upload(uri *url.URL, projectID string, baseLang langCode) (err error) {
-	uploadURI := uri.JoinPath("upload")
-
+// upload base translation via p.  baseLang is the base language code.
+func upload(p provider.Provider, baseLang langCode) (err error) {
 	lang := baseLang
 
 	langStr := os.Getenv("UPLOAD_LANGUAGE")
@@ -441,23 +669,9 @@ func upload(uri *url.URL, projectID string, baseLang langCode) (err error) {
 		return fmt.Errorf("upload: %w", err)
 	}
 
-	var buf bytes.Buffer
-	buf.Write(b)
-
-	uri = translationURL(uploadURI, defaultBaseFile, projectID, lang)
-
-	var client http.Client
-	resp, err := client.Post(uri.String(), "application/json", &buf)
+	err = p.Upload(context.Background(), string(lang), b)
 	if err != nil {
-		return fmt.Errorf("upload: client post: %w", err)
-	}
-
-	defer func() {
-		err = errors.WithDeferred(err, resp.Body.Close())
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("status code is not ok: %q", http.StatusText(resp.StatusCode))
+		return fmt.Errorf("upload: %w", err)
 	}
 
 	return nil
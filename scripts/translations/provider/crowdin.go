@@ -0,0 +1,228 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/AdguardTeam/golibs/errors"
+	"github.com/AdguardTeam/golibs/log"
+)
+
+// crowdinAPI is the base URL of the Crowdin v2 REST API.
+const crowdinAPI = "https://api.crowdin.com/api/v2"
+
+// crowdin is a Provider that speaks the Crowdin v2 REST API.  See
+// https://developer.crowdin.com/api/v2/ for the endpoints used here.
+type crowdin struct {
+	client    *http.Client
+	token     string
+	projectID string
+	baseFile  string
+	timeout   time.Duration
+}
+
+// newCrowdin returns a new crowdin provider.
+func newCrowdin(conf *Config) (p Provider, err error) {
+	if conf.Token == "" {
+		return nil, errors.Error("crowdin: CROWDIN_TOKEN is required")
+	}
+
+	client := conf.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	return &crowdin{
+		client:    client,
+		token:     conf.Token,
+		projectID: conf.ProjectID,
+		baseFile:  conf.BaseFile,
+		timeout:   timeout,
+	}, nil
+}
+
+// type check
+var _ Provider = (*crowdin)(nil)
+
+// request performs an authenticated request against the Crowdin API,
+// retrying as httpDoRetry does, and decodes the JSON response body into v,
+// unless v is nil.
+func (c *crowdin) request(ctx context.Context, method, path string, body, v any) (err error) {
+	var reqBody []byte
+	if body != nil {
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("crowdin: marshalling request: %w", err)
+		}
+	}
+
+	resp, cancel, err := httpDoRetry(ctx, c.client, c.timeout, func(reqCtx context.Context) (*http.Request, error) {
+		var bodyReader io.Reader
+		if reqBody != nil {
+			bodyReader = bytes.NewReader(reqBody)
+		}
+
+		req, rErr := http.NewRequestWithContext(reqCtx, method, crowdinAPI+path, bodyReader)
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		if reqBody != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		return req, nil
+	})
+	defer cancel()
+	if err != nil {
+		return fmt.Errorf("crowdin: requesting %s: %w", path, err)
+	}
+	defer log.OnCloserError(resp.Body, log.ERROR)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("crowdin: %s: status code: %s", path, http.StatusText(resp.StatusCode))
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("crowdin: decoding response from %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Download implements the Provider interface for *crowdin.  It builds a
+// translation export for lang and fetches the result.
+func (c *crowdin) Download(ctx context.Context, lang string, progress func(read, total int64)) (data []byte, err error) {
+	var export struct {
+		Data struct {
+			URL string `json:"url"`
+		} `json:"data"`
+	}
+
+	exportReq := map[string]any{
+		"targetLanguageId": lang,
+	}
+
+	err = c.request(ctx, http.MethodPost, "/projects/"+c.projectID+"/translations/exports", exportReq, &export)
+	if err != nil {
+		return nil, fmt.Errorf("crowdin: exporting %q: %w", lang, err)
+	}
+
+	resp, cancel, err := httpDoRetry(ctx, c.client, c.timeout, func(reqCtx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(reqCtx, http.MethodGet, export.Data.URL, nil)
+	})
+	defer cancel()
+	if err != nil {
+		return nil, fmt.Errorf("crowdin: downloading %q: %w", lang, err)
+	}
+	defer log.OnCloserError(resp.Body, log.ERROR)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("crowdin: downloading %q: status code: %s", lang, http.StatusText(resp.StatusCode))
+	}
+
+	data, err = readAllProgress(resp.Body, resp.ContentLength, progress)
+	if err != nil {
+		return nil, fmt.Errorf("crowdin: reading %q: %w", lang, err)
+	}
+
+	return data, nil
+}
+
+// Upload implements the Provider interface for *crowdin.  It stores data and
+// attaches it as the translation for lang.
+func (c *crowdin) Upload(ctx context.Context, lang string, data []byte) (err error) {
+	var storage struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+
+	resp, cancel, err := httpDoRetry(ctx, c.client, c.timeout, func(reqCtx context.Context) (*http.Request, error) {
+		req, rErr := http.NewRequestWithContext(reqCtx, http.MethodPost, crowdinAPI+"/storages", bytes.NewReader(data))
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Crowdin-API-FileName", c.baseFile)
+
+		return req, nil
+	})
+	defer cancel()
+	if err != nil {
+		return fmt.Errorf("crowdin: storing %q: %w", lang, err)
+	}
+
+	err = func() (err error) {
+		defer log.OnCloserError(resp.Body, log.ERROR)
+
+		if resp.StatusCode != http.StatusCreated {
+			return fmt.Errorf("crowdin: storing %q: status code: %s", lang, http.StatusText(resp.StatusCode))
+		}
+
+		return json.NewDecoder(resp.Body).Decode(&storage)
+	}()
+	if err != nil {
+		return err
+	}
+
+	fileID, err := c.fileID(ctx)
+	if err != nil {
+		return fmt.Errorf("crowdin: resolving file %q: %w", c.baseFile, err)
+	}
+
+	uploadReq := map[string]any{
+		"storageId":           storage.Data.ID,
+		"fileId":              fileID,
+		"importEqSuggestions": false,
+	}
+
+	path := fmt.Sprintf("/projects/%s/translations/%s", c.projectID, lang)
+
+	return c.request(ctx, http.MethodPost, path, uploadReq, nil)
+}
+
+// fileID returns the Crowdin file ID of c.baseFile within the project, as
+// required by the translations-upload endpoint.
+func (c *crowdin) fileID(ctx context.Context) (id int, err error) {
+	var resp struct {
+		Data []struct {
+			Data struct {
+				ID   int    `json:"id"`
+				Name string `json:"name"`
+			} `json:"data"`
+		} `json:"data"`
+	}
+
+	path := "/projects/" + c.projectID + "/files"
+	err = c.request(ctx, http.MethodGet, path, nil, &resp)
+	if err != nil {
+		return 0, fmt.Errorf("listing files: %w", err)
+	}
+
+	for _, f := range resp.Data {
+		if f.Data.Name == c.baseFile {
+			return f.Data.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("file %q not found in project", c.baseFile)
+}
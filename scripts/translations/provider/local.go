@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// local is a Provider that reads and writes a plain directory tree of
+// "<lang>.json" files.  It is meant for offline testing of the translations
+// tool without reaching a real translation service.
+type local struct {
+	dir string
+}
+
+// newLocal returns a new local provider.
+func newLocal(conf *Config) (p Provider, err error) {
+	if conf.LocalDir == "" {
+		return nil, fmt.Errorf("local: directory is required")
+	}
+
+	return &local{
+		dir: conf.LocalDir,
+	}, nil
+}
+
+// type check
+var _ Provider = (*local)(nil)
+
+// Download implements the Provider interface for *local.  The file is read
+// in one go, so progress, if not nil, is only ever called once, with read
+// equal to total.
+func (l *local) Download(_ context.Context, lang string, progress func(read, total int64)) (data []byte, err error) {
+	data, err = os.ReadFile(filepath.Join(l.dir, lang+".json"))
+	if err != nil {
+		// Don't wrap the error since it's informative enough as is.
+		return nil, err
+	}
+
+	if progress != nil {
+		progress(int64(len(data)), int64(len(data)))
+	}
+
+	return data, nil
+}
+
+// Upload implements the Provider interface for *local.
+func (l *local) Upload(_ context.Context, lang string, data []byte) (err error) {
+	err = os.WriteFile(filepath.Join(l.dir, lang+".json"), data, 0o644)
+	if err != nil {
+		// Don't wrap the error since it's informative enough as is.
+		return err
+	}
+
+	return nil
+}
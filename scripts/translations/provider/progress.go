@@ -0,0 +1,33 @@
+package provider
+
+import "io"
+
+// progressReader wraps r and invokes onProgress after every read with the
+// cumulative number of bytes read so far and total, the expected size (0 if
+// unknown).
+type progressReader struct {
+	r          io.Reader
+	total      int64
+	read       int64
+	onProgress func(read, total int64)
+}
+
+// Read implements the io.Reader interface for *progressReader.
+func (p *progressReader) Read(b []byte) (n int, err error) {
+	n, err = p.r.Read(b)
+	p.read += int64(n)
+	p.onProgress(p.read, p.total)
+
+	return n, err
+}
+
+// readAllProgress reads all of r and returns the result, like io.ReadAll,
+// calling onProgress after every chunk read if it isn't nil.  total is the
+// expected size of r's contents, or <= 0 if unknown.
+func readAllProgress(r io.Reader, total int64, onProgress func(read, total int64)) (data []byte, err error) {
+	if onProgress == nil {
+		return io.ReadAll(r)
+	}
+
+	return io.ReadAll(&progressReader{r: r, total: total, onProgress: onProgress})
+}
@@ -0,0 +1,83 @@
+// Package provider defines the translation backend abstraction used by the
+// translations tool, along with the backends it ships with.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// defaultRequestTimeout is the deadline given to a single HTTP request
+// attempt, including retries, when Config.Timeout isn't set.
+const defaultRequestTimeout = 30 * time.Second
+
+// Name is the identifier of a Provider implementation, as configured in
+// .twosky.json or the TRANSLATION_PROVIDER environment variable.
+type Name string
+
+// Provider names supported by New.
+const (
+	NameTwosky  Name = "twosky"
+	NameCrowdin Name = "crowdin"
+	NameLocal   Name = "local"
+)
+
+// Provider is a translation backend.  Implementations are not required to be
+// safe for concurrent use unless stated otherwise.
+type Provider interface {
+	// Download returns the translation data for lang.  If progress is not
+	// nil, it is called after every chunk read with the cumulative number
+	// of bytes read so far and the total size, which is 0 if unknown.
+	Download(ctx context.Context, lang string, progress func(read, total int64)) (data []byte, err error)
+
+	// Upload writes data as the translation for lang.
+	Upload(ctx context.Context, lang string, data []byte) (err error)
+}
+
+// Config is the set of parameters needed to construct any Provider.  Not
+// every field is used by every implementation; see the individual
+// constructors for details.
+type Config struct {
+	// Client is the HTTP client used by network-backed providers.  If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+
+	// BaseURL is the base API URL.  Used by the twosky provider.
+	BaseURL string
+
+	// Token is the bearer token used by the crowdin provider.
+	Token string
+
+	// ProjectID is the project identifier.  Used by the twosky and crowdin
+	// providers.
+	ProjectID string
+
+	// BaseLangCode is the base, or source, language code.
+	BaseLangCode string
+
+	// BaseFile is the name of the base localization file, e.g. "en.json".
+	BaseFile string
+
+	// LocalDir is the directory tree used by the local provider.
+	LocalDir string
+
+	// Timeout bounds a single HTTP request attempt, including retries.  If
+	// zero, defaultRequestTimeout is used.
+	Timeout time.Duration
+}
+
+// New returns the Provider identified by name.
+func New(name Name, conf *Config) (p Provider, err error) {
+	switch name {
+	case "", NameTwosky:
+		return newTwosky(conf)
+	case NameCrowdin:
+		return newCrowdin(conf)
+	case NameLocal:
+		return newLocal(conf)
+	default:
+		return nil, fmt.Errorf("provider: unknown provider %q", name)
+	}
+}
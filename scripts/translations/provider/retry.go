@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry tuning for httpDoRetry.  maxAttempts bounds the total number of
+// tries; backoff grows exponentially between baseDelay and maxDelay, with
+// up to 50% jitter, unless the server names a wait via Retry-After.
+const (
+	maxAttempts = 5
+	baseDelay   = 200 * time.Millisecond
+	maxDelay    = 10 * time.Second
+)
+
+// httpDoRetry performs an HTTP request built by newReq, retrying on 429,
+// 5xx, and timed-out network errors with exponential backoff and jitter,
+// honoring a Retry-After response header when present.  newReq is called
+// once per attempt, with a context deadlined to perRequestTimeout, so that a
+// slow attempt can't hang the whole retry sequence and every retry gets a
+// fresh request body.
+//
+// The returned cancel must be called once the caller is done reading resp's
+// body: a request's context governs the body reads as well as establishing
+// the connection, so canceling it any earlier would risk turning an
+// in-flight read into a bogus "context canceled" error.
+func httpDoRetry(
+	ctx context.Context,
+	client *http.Client,
+	perRequestTimeout time.Duration,
+	newReq func(ctx context.Context) (*http.Request, error),
+) (resp *http.Response, cancel context.CancelFunc, err error) {
+	for attempt := 0; ; attempt++ {
+		reqCtx, reqCancel := context.WithTimeout(ctx, perRequestTimeout)
+
+		var req *http.Request
+		req, err = newReq(reqCtx)
+		if err != nil {
+			reqCancel()
+
+			return nil, noopCancel, err
+		}
+
+		resp, err = client.Do(req)
+
+		retry, wait := shouldRetry(attempt, resp, err)
+		if !retry {
+			return resp, reqCancel, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		reqCancel()
+
+		select {
+		case <-ctx.Done():
+			return nil, noopCancel, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// noopCancel is a context.CancelFunc that does nothing, returned by
+// httpDoRetry alongside a nil response so that callers can unconditionally
+// defer the cancel function they get back.
+func noopCancel() {}
+
+// shouldRetry decides whether the result of an attempt is worth retrying,
+// and if so, how long to wait first.
+func shouldRetry(attempt int, resp *http.Response, err error) (retry bool, wait time.Duration) {
+	if attempt >= maxAttempts-1 {
+		return false, 0
+	}
+
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true, backoff(attempt)
+		}
+
+		return false, 0
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < http.StatusInternalServerError {
+		return false, 0
+	}
+
+	if d, ok := retryAfter(resp); ok {
+		return true, d
+	}
+
+	return true, backoff(attempt)
+}
+
+// retryAfter parses the Retry-After header, in either its delay-seconds or
+// HTTP-date form.
+func retryAfter(resp *http.Response) (d time.Duration, ok bool) {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t), true
+	}
+
+	return 0, false
+}
+
+// backoff returns the exponential delay for attempt, jittered by up to 50%.
+func backoff(attempt int) (d time.Duration) {
+	d = baseDelay * time.Duration(int64(1)<<attempt)
+	if d > maxDelay {
+		d = maxDelay
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
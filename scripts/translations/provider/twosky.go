@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/AdguardTeam/AdGuardHome/internal/aghio"
+	"github.com/AdguardTeam/golibs/errors"
+	"github.com/AdguardTeam/golibs/log"
+)
+
+// readLimit is the maximum size of a single downloaded translation file.
+const readLimit = 1 * 1024 * 1024
+
+// twosky is a Provider that talks to the twosky.int.agrd.dev translation
+// service.
+type twosky struct {
+	client    *http.Client
+	baseURL   *url.URL
+	projectID string
+	baseFile  string
+	timeout   time.Duration
+}
+
+// newTwosky returns a new twosky provider.
+func newTwosky(conf *Config) (p Provider, err error) {
+	baseURL, err := url.Parse(conf.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("twosky: parsing base url: %w", err)
+	}
+
+	client := conf.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	return &twosky{
+		client:    client,
+		baseURL:   baseURL,
+		projectID: conf.ProjectID,
+		baseFile:  conf.BaseFile,
+		timeout:   timeout,
+	}, nil
+}
+
+// type check
+var _ Provider = (*twosky)(nil)
+
+// translationURL returns a new URL with the query parameters twosky expects.
+func (t *twosky) translationURL(action, lang string) (uri *url.URL) {
+	uri = t.baseURL.JoinPath(action)
+
+	q := uri.Query()
+	q.Set("format", "json")
+	q.Set("filename", t.baseFile)
+	q.Set("project", t.projectID)
+	q.Set("language", lang)
+	uri.RawQuery = q.Encode()
+
+	return uri
+}
+
+// Download implements the Provider interface for *twosky.
+func (t *twosky) Download(ctx context.Context, lang string, progress func(read, total int64)) (data []byte, err error) {
+	uri := t.translationURL("download", lang)
+
+	resp, cancel, err := httpDoRetry(ctx, t.client, t.timeout, func(reqCtx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(reqCtx, http.MethodGet, uri.String(), nil)
+	})
+	defer cancel()
+	if err != nil {
+		return nil, fmt.Errorf("twosky: requesting: %w", err)
+	}
+	defer log.OnCloserError(resp.Body, log.ERROR)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("twosky: url: %q; status code: %s", uri, http.StatusText(resp.StatusCode))
+	}
+
+	limitReader, err := aghio.LimitReader(resp.Body, readLimit)
+	if err != nil {
+		return nil, fmt.Errorf("twosky: limit reading: %w", err)
+	}
+
+	data, err = readAllProgress(limitReader, resp.ContentLength, progress)
+	if err != nil {
+		return nil, fmt.Errorf("twosky: reading all: %w", err)
+	}
+
+	return data, nil
+}
+
+// Upload implements the Provider interface for *twosky.
+func (t *twosky) Upload(ctx context.Context, lang string, data []byte) (err error) {
+	uri := t.translationURL("upload", lang)
+
+	resp, cancel, err := httpDoRetry(ctx, t.client, t.timeout, func(reqCtx context.Context) (*http.Request, error) {
+		req, rErr := http.NewRequestWithContext(reqCtx, http.MethodPost, uri.String(), bytes.NewReader(data))
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		return req, nil
+	})
+	defer cancel()
+	if err != nil {
+		return fmt.Errorf("twosky: requesting: %w", err)
+	}
+	defer func() {
+		err = errors.WithDeferred(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("twosky: status code is not ok: %q", http.StatusText(resp.StatusCode))
+	}
+
+	return nil
+}